@@ -11,13 +11,8 @@ package auth
 
 import (
 	"crypto"
-	"crypto/rand"
-	"crypto/rsa"
-	"crypto/x509"
 	"encoding/base64"
-	"encoding/pem"
 	"fmt"
-	"io/ioutil"
 	"net/http"
 	"strings"
 )
@@ -32,6 +27,8 @@ type Endpoint struct {
 	URL string
 }
 
+// Algorithm may be left empty; PrivateKeySigner then derives it from the key
+// once loaded, see DeriveAlgorithm.
 type Auth struct {
 	User      string
 	KeyFile   string
@@ -46,54 +43,38 @@ type Credentials struct {
 	MantaEndpoint      Endpoint
 }
 
-type PrivateKey struct {
-	key *rsa.PrivateKey
-}
-
-// The CreateAuthorizationHeader returns the Authorization header for the give request.
-func CreateAuthorizationHeader(headers http.Header, credentials *Credentials, isMantaRequest bool) (string, error) {
+// The CreateAuthorizationHeader returns the Authorization header for the give request,
+// signed with signer. It only ever signs the Date header; use SignRequest to sign an
+// arbitrary list of headers per the HTTP Signatures draft (the scheme this function
+// implements is a degenerate, single-header case of it).
+func CreateAuthorizationHeader(headers http.Header, credentials *Credentials, signer Signer, isMantaRequest bool) (string, error) {
 	if isMantaRequest {
-		signature, err := GetSignature(&credentials.UserAuthentication, "date: "+headers.Get("Date"))
+		algorithm, signature, err := GetSignature(signer, "date: "+headers.Get("Date"))
 		if err != nil {
 			return "", err
 		}
 		return fmt.Sprintf(MantaSignature, credentials.UserAuthentication.User, credentials.MantaKeyId,
-			credentials.UserAuthentication.Algorithm, signature), nil
+			algorithm, signature), nil
 	}
-	signature, err := GetSignature(&credentials.UserAuthentication, headers.Get("Date"))
+	algorithm, signature, err := GetSignature(signer, headers.Get("Date"))
 	if err != nil {
 		return "", err
 	}
 	return fmt.Sprintf(SdcSignature, credentials.UserAuthentication.User, credentials.SdcKeyId,
-		credentials.UserAuthentication.Algorithm, signature), nil
+		algorithm, signature), nil
 }
 
-// The GetSignature method signs the specified key according to http://apidocs.joyent.com/cloudapi/#issuing-requests
-// and http://apidocs.joyent.com/manta/api.html#authentication.
-func GetSignature(auth *Auth, signing string) (string, error) {
-	key, err := ioutil.ReadFile(auth.KeyFile)
-	if err != nil {
-		return "", fmt.Errorf("An error occurred while reading the key: %s", err)
-	}
-	block, _ := pem.Decode(key)
-	rsakey, err := x509.ParsePKCS1PrivateKey(block.Bytes)
-	if err != nil {
-		return "", fmt.Errorf("An error occurred while parsing the key: %s", err)
-	}
-	privateKey := &PrivateKey{rsakey}
-
-	hashFunc := getHashFunction(auth.Algorithm)
-	hash := hashFunc.New()
-	hash.Write([]byte(signing))
-
-	digest := hash.Sum(nil)
-
-	signed, err := rsa.SignPKCS1v15(rand.Reader, privateKey.key, hashFunc, digest)
+// The GetSignature method signs the specified string with signer according to
+// http://apidocs.joyent.com/cloudapi/#issuing-requests and
+// http://apidocs.joyent.com/manta/api.html#authentication. It returns the
+// algorithm signer actually signed with, which may differ from any
+// configured Algorithm when the latter was left empty for auto-detection.
+func GetSignature(signer Signer, signing string) (algorithm string, signature string, err error) {
+	algorithm, signed, err := signer.Sign([]byte(signing))
 	if err != nil {
-		return "", fmt.Errorf("An error occurred while signing the key: %s", err)
+		return "", "", err
 	}
-
-	return base64.StdEncoding.EncodeToString(signed), nil
+	return algorithm, base64.StdEncoding.EncodeToString(signed), nil
 }
 
 // Helper method to get the Hash function based on the algorithm
@@ -101,9 +82,11 @@ func getHashFunction(algorithm string) (hashFunc crypto.Hash) {
 	switch strings.ToLower(algorithm) {
 	case "rsa-sha1":
 		hashFunc = crypto.SHA1
-	case "rsa-sha224", "rsa-sha256":
+	case "rsa-sha224", "rsa-sha256", "ecdsa-sha256":
 		hashFunc = crypto.SHA256
-	case "rsa-sha384", "rsa-sha512":
+	case "ecdsa-sha384":
+		hashFunc = crypto.SHA384
+	case "rsa-sha384", "rsa-sha512", "ecdsa-sha512":
 		hashFunc = crypto.SHA512
 	default:
 		hashFunc = crypto.SHA256
@@ -0,0 +1,116 @@
+//
+// gosign - Go HTTP signing library for the Joyent Public Cloud and Joyent Manta
+//
+//
+// Copyright (c) 2013 Joyent Inc.
+//
+
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writePKCS8KeyFile(t *testing.T, dir, name string, key crypto.PrivateKey) string {
+	t.Helper()
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("failed to marshal key: %s", err)
+	}
+	path := filepath.Join(dir, name)
+	data := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatalf("failed to write key file: %s", err)
+	}
+	return path
+}
+
+func TestSignRequestVerifyRequestRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate RSA key: %s", err)
+	}
+	ecP256Key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate P256 key: %s", err)
+	}
+	ecP384Key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate P384 key: %s", err)
+	}
+	ecP521Key, err := ecdsa.GenerateKey(elliptic.P521(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate P521 key: %s", err)
+	}
+	_, ed25519Key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate Ed25519 key: %s", err)
+	}
+
+	tests := []struct {
+		name          string
+		key           crypto.PrivateKey
+		publicKey     crypto.PublicKey
+		wantAlgorithm string
+	}{
+		{"rsa", rsaKey, &rsaKey.PublicKey, "rsa-sha256"},
+		{"ecdsa-p256", ecP256Key, &ecP256Key.PublicKey, "ecdsa-sha256"},
+		{"ecdsa-p384", ecP384Key, &ecP384Key.PublicKey, "ecdsa-sha384"},
+		{"ecdsa-p521", ecP521Key, &ecP521Key.PublicKey, "ecdsa-sha512"},
+		{"ed25519", ed25519Key, ed25519Key.Public(), "ed25519"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := DeriveAlgorithm(test.key); got != test.wantAlgorithm {
+				t.Fatalf("DeriveAlgorithm: got %q, want %q", got, test.wantAlgorithm)
+			}
+
+			keyFile := writePKCS8KeyFile(t, dir, test.name+".pem", test.key)
+			signer := NewPrivateKeySigner(keyFile, "/user/keys/"+test.name, "", nil)
+
+			req, err := http.NewRequest("POST", "https://example.com/path?a=b", strings.NewReader(`{"hello":"world"}`))
+			if err != nil {
+				t.Fatalf("failed to build request: %s", err)
+			}
+			req.Header.Set("Date", "Thu, 05 Jan 2026 21:31:40 GMT")
+			req.Header.Set("Host", req.URL.Host)
+
+			credentials := &Credentials{
+				UserAuthentication: Auth{User: "user"},
+				MantaKeyId:         test.name,
+			}
+			headers := []string{RequestTarget, "date", "host", "digest"}
+			if err := SignRequest(req, credentials, signer, headers, true); err != nil {
+				t.Fatalf("SignRequest failed: %s", err)
+			}
+			if req.Header.Get("Digest") == "" {
+				t.Fatalf("expected Digest header to be set")
+			}
+
+			if err := VerifyRequest(req, test.publicKey); err != nil {
+				t.Fatalf("VerifyRequest failed: %s", err)
+			}
+
+			// Tampering with a signed header must invalidate the signature.
+			req.Header.Set("Host", "attacker.example.com")
+			if err := VerifyRequest(req, test.publicKey); err == nil {
+				t.Fatalf("expected VerifyRequest to fail after tampering")
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+//
+// gosign - Go HTTP signing library for the Joyent Public Cloud and Joyent Manta
+//
+//
+// Copyright (c) 2013 Joyent Inc.
+//
+
+package auth
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"fmt"
+	"hash"
+	"io"
+	"strings"
+)
+
+// ComputeDigest reads body to the end and hashes it with algo ("SHA-256",
+// "SHA-512" or "MD5", defaulting to "SHA-256") through an io.TeeReader that
+// keeps a single in-memory copy of the bytes read, so the caller does not
+// have to buffer the body itself as well. The whole body is held in memory
+// until the returned reader is consumed. It returns the resulting Digest
+// header value, e.g. "SHA-256=base64(...)", and a reader that replays the
+// bytes read from body for the caller to actually send.
+func ComputeDigest(body io.Reader, algo string) (header string, wrapped io.Reader, err error) {
+	name, hasher, err := newDigestHash(algo)
+	if err != nil {
+		return "", nil, err
+	}
+	var buf bytes.Buffer
+	if _, err := io.Copy(hasher, io.TeeReader(body, &buf)); err != nil {
+		return "", nil, fmt.Errorf("an error occurred while computing the digest: %s", err)
+	}
+	header = fmt.Sprintf("%s=%s", name, base64.StdEncoding.EncodeToString(hasher.Sum(nil)))
+	return header, &buf, nil
+}
+
+// VerifyDigest checks that body hashes to the value recorded in header, a
+// Digest header value as produced by ComputeDigest, e.g.
+// "SHA-256=base64(...)".
+func VerifyDigest(header string, body io.Reader) error {
+	parts := strings.SplitN(header, "=", 2)
+	if len(parts) != 2 {
+		return fmt.Errorf("malformed Digest header %q", header)
+	}
+	algo, want := parts[0], parts[1]
+	_, hasher, err := newDigestHash(algo)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(hasher, body); err != nil {
+		return fmt.Errorf("an error occurred while computing the digest: %s", err)
+	}
+	if got := base64.StdEncoding.EncodeToString(hasher.Sum(nil)); got != want {
+		return fmt.Errorf("digest mismatch: expected %s, got %s", want, got)
+	}
+	return nil
+}
+
+// newDigestHash returns the canonical Digest algorithm name and a fresh
+// hash.Hash for algo.
+func newDigestHash(algo string) (name string, h hash.Hash, err error) {
+	switch strings.ToUpper(algo) {
+	case "", "SHA-256":
+		return "SHA-256", sha256.New(), nil
+	case "SHA-512":
+		return "SHA-512", sha512.New(), nil
+	case "MD5":
+		return "MD5", md5.New(), nil
+	default:
+		return "", nil, fmt.Errorf("unsupported digest algorithm %q", algo)
+	}
+}
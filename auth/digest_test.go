@@ -0,0 +1,86 @@
+//
+// gosign - Go HTTP signing library for the Joyent Public Cloud and Joyent Manta
+//
+//
+// Copyright (c) 2013 Joyent Inc.
+//
+
+package auth
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestComputeDigestAndVerifyDigest(t *testing.T) {
+	tests := []struct {
+		name string
+		algo string
+		want string
+	}{
+		{"sha256", "SHA-256", "SHA-256"},
+		{"sha256-default", "", "SHA-256"},
+		{"sha512", "SHA-512", "SHA-512"},
+		{"md5", "MD5", "MD5"},
+	}
+
+	body := "the quick brown fox jumps over the lazy dog"
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			header, wrapped, err := ComputeDigest(strings.NewReader(body), test.algo)
+			if err != nil {
+				t.Fatalf("ComputeDigest failed: %s", err)
+			}
+			if !strings.HasPrefix(header, test.want+"=") {
+				t.Fatalf("expected header to start with %q=, got %s", test.want, header)
+			}
+
+			replayed, err := io.ReadAll(wrapped)
+			if err != nil {
+				t.Fatalf("failed to read wrapped reader: %s", err)
+			}
+			if string(replayed) != body {
+				t.Fatalf("wrapped reader replayed %q, want %q", replayed, body)
+			}
+
+			if err := VerifyDigest(header, strings.NewReader(body)); err != nil {
+				t.Fatalf("VerifyDigest failed: %s", err)
+			}
+		})
+	}
+}
+
+func TestVerifyDigestDetectsTampering(t *testing.T) {
+	header, _, err := ComputeDigest(strings.NewReader("original body"), "SHA-256")
+	if err != nil {
+		t.Fatalf("ComputeDigest failed: %s", err)
+	}
+	if err := VerifyDigest(header, strings.NewReader("tampered body")); err == nil {
+		t.Fatalf("expected VerifyDigest to detect a tampered body")
+	}
+}
+
+func TestVerifyDigestRejectsMalformedHeader(t *testing.T) {
+	if err := VerifyDigest("not-a-digest-header", strings.NewReader("body")); err == nil {
+		t.Fatalf("expected VerifyDigest to reject a header with no algorithm")
+	}
+}
+
+func TestComputeDigestRejectsUnsupportedAlgorithm(t *testing.T) {
+	if _, _, err := ComputeDigest(strings.NewReader("body"), "CRC32"); err == nil {
+		t.Fatalf("expected ComputeDigest to reject an unsupported algorithm")
+	}
+}
+
+func TestComputeDigestReplayedBodyRoundTripsThroughVerifyDigest(t *testing.T) {
+	body := []byte(`{"hello":"world"}`)
+	header, wrapped, err := ComputeDigest(bytes.NewReader(body), "SHA-256")
+	if err != nil {
+		t.Fatalf("ComputeDigest failed: %s", err)
+	}
+	if err := VerifyDigest(header, wrapped); err != nil {
+		t.Fatalf("VerifyDigest on the replayed reader failed: %s", err)
+	}
+}
@@ -0,0 +1,49 @@
+//
+// gosign - Go HTTP signing library for the Joyent Public Cloud and Joyent Manta
+//
+//
+// Copyright (c) 2013 Joyent Inc.
+//
+
+package auth
+
+import (
+	"crypto"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// FingerprintMD5 returns the SSH-style MD5 fingerprint of pub, e.g.
+// "ab:cd:ef:...", the form `ssh-keygen -l` has historically printed and
+// that SDC/Manta key ids are derived from. pub may be an *rsa.PublicKey,
+// *ecdsa.PublicKey or ed25519.PublicKey.
+func FingerprintMD5(pub crypto.PublicKey) (string, error) {
+	sshPub, err := ssh.NewPublicKey(pub)
+	if err != nil {
+		return "", fmt.Errorf("an error occurred while converting the public key: %s", err)
+	}
+	sum := md5.Sum(sshPub.Marshal())
+	hexSum := hex.EncodeToString(sum[:])
+	pairs := make([]string, len(hexSum)/2)
+	for i := range pairs {
+		pairs[i] = hexSum[i*2 : i*2+2]
+	}
+	return strings.Join(pairs, ":"), nil
+}
+
+// FingerprintSHA256 returns the newer SHA-256 form of pub's SSH fingerprint,
+// e.g. "SHA256:<base64>", as printed by `ssh-keygen -l -E sha256`.
+func FingerprintSHA256(pub crypto.PublicKey) (string, error) {
+	sshPub, err := ssh.NewPublicKey(pub)
+	if err != nil {
+		return "", fmt.Errorf("an error occurred while converting the public key: %s", err)
+	}
+	sum := sha256.Sum256(sshPub.Marshal())
+	return "SHA256:" + base64.RawStdEncoding.EncodeToString(sum[:]), nil
+}
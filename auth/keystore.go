@@ -0,0 +1,211 @@
+//
+// gosign - Go HTTP signing library for the Joyent Public Cloud and Joyent Manta
+//
+//
+// Copyright (c) 2013 Joyent Inc.
+//
+
+package auth
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// KeyStore holds one or more private keys, indexed by their SSH
+// fingerprint, so a Credentials can reference a key by fingerprint rather
+// than a static SdcKeyId/MantaKeyId string, and so long-running Manta/SDC
+// clients can rotate keys by adding new key files rather than restarting.
+type KeyStore struct {
+	mu          sync.RWMutex
+	signers     map[string]*PrivateKeySigner
+	order       []string
+	loadedFiles map[string]bool
+}
+
+// NewKeyStore returns an empty KeyStore.
+func NewKeyStore() *KeyStore {
+	return &KeyStore{
+		signers:     make(map[string]*PrivateKeySigner),
+		loadedFiles: make(map[string]bool),
+	}
+}
+
+// AddKeyFile loads the private key in keyFile, derives its MD5 and SHA-256
+// SSH fingerprints and registers a Signer for the key under both. It
+// returns the MD5 fingerprint, which is what SdcKeyId/MantaKeyId have
+// traditionally held. If algorithm is empty it is derived from the key.
+func (ks *KeyStore) AddKeyFile(keyFile, algorithm string, passphrase []byte) (string, error) {
+	key, err := loadPrivateKey(keyFile, passphrase)
+	if err != nil {
+		return "", err
+	}
+	if algorithm == "" {
+		algorithm = DeriveAlgorithm(key)
+	}
+	md5Fingerprint, err := FingerprintMD5(key.Public())
+	if err != nil {
+		return "", err
+	}
+	sha256Fingerprint, err := FingerprintSHA256(key.Public())
+	if err != nil {
+		return "", err
+	}
+
+	signer := NewPrivateKeySigner(keyFile, md5Fingerprint, algorithm, passphrase)
+	signer.key = key
+
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	ks.signers[md5Fingerprint] = signer
+	ks.signers[sha256Fingerprint] = signer
+	ks.order = append(ks.order, md5Fingerprint)
+	ks.loadedFiles[filepath.Base(keyFile)] = true
+	return md5Fingerprint, nil
+}
+
+// SignerFor returns the Signer registered under fingerprint, which may be
+// either the MD5 or the SHA-256 form.
+func (ks *KeyStore) SignerFor(fingerprint string) (Signer, bool) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	signer, ok := ks.signers[fingerprint]
+	return signer, ok
+}
+
+// signerForCredentials resolves the Signer for credentials' key id —
+// MantaKeyId for a Manta request, SdcKeyId otherwise — letting that field
+// hold a fingerprint rather than a static key id. If the field is empty it
+// falls back to PreferredSigner; if it names a fingerprint ks doesn't hold,
+// that is an error rather than a silent fallback, since signing with the
+// wrong key would leave the Signature header's keyId not matching what
+// actually signed the request.
+func (ks *KeyStore) signerForCredentials(credentials *Credentials, isMantaRequest bool) (Signer, error) {
+	fingerprint := credentials.SdcKeyId
+	if isMantaRequest {
+		fingerprint = credentials.MantaKeyId
+	}
+	if fingerprint == "" {
+		if signer, ok := ks.PreferredSigner(); ok {
+			return signer, nil
+		}
+		return nil, fmt.Errorf("no key loaded in KeyStore")
+	}
+	if signer, ok := ks.SignerFor(fingerprint); ok {
+		return signer, nil
+	}
+	return nil, fmt.Errorf("no key loaded matching %q", fingerprint)
+}
+
+// CreateAuthorizationHeader resolves credentials' key id to a Signer via
+// signerForCredentials and delegates to the package-level
+// CreateAuthorizationHeader, so a long-running client can rotate keys by
+// adding them to ks rather than hardcoding SdcKeyId/MantaKeyId.
+func (ks *KeyStore) CreateAuthorizationHeader(headers http.Header, credentials *Credentials, isMantaRequest bool) (string, error) {
+	signer, err := ks.signerForCredentials(credentials, isMantaRequest)
+	if err != nil {
+		return "", err
+	}
+	return CreateAuthorizationHeader(headers, credentials, signer, isMantaRequest)
+}
+
+// SignRequest resolves credentials' key id to a Signer via
+// signerForCredentials and delegates to the package-level SignRequest, so a
+// long-running client can rotate keys by adding them to ks rather than
+// hardcoding SdcKeyId/MantaKeyId.
+func (ks *KeyStore) SignRequest(req *http.Request, credentials *Credentials, signHeaders []string, isMantaRequest bool) error {
+	signer, err := ks.signerForCredentials(credentials, isMantaRequest)
+	if err != nil {
+		return err
+	}
+	return SignRequest(req, credentials, signer, signHeaders, isMantaRequest)
+}
+
+// algorithmStrength ranks algorithms by cryptographic strength, for
+// PreferredSigner. Ed25519 and the larger ECDSA curves rank above RSA.
+func algorithmStrength(algorithm string) int {
+	switch algorithm {
+	case "ed25519":
+		return 4
+	case "ecdsa-sha512":
+		return 3
+	case "ecdsa-sha384":
+		return 2
+	case "ecdsa-sha256":
+		return 1
+	default:
+		return 0
+	}
+}
+
+// PreferredSigner returns the Signer for the strongest algorithm among the
+// keys loaded into ks.
+func (ks *KeyStore) PreferredSigner() (Signer, bool) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	var best *PrivateKeySigner
+	bestStrength := -1
+	for _, fingerprint := range ks.order {
+		signer := ks.signers[fingerprint]
+		if strength := algorithmStrength(signer.algorithm); strength > bestStrength {
+			best, bestStrength = signer, strength
+		}
+	}
+	if best == nil {
+		return nil, false
+	}
+	return best, true
+}
+
+// Watch polls dir every interval for new "*.pem" files and loads any that
+// are not already known, so a long-running Manta/SDC client can rotate
+// keys onto disk without a process restart. The returned stop function
+// ends the watch.
+func (ks *KeyStore) Watch(dir string, interval time.Duration) (stop func(), err error) {
+	if err := ks.scanDir(dir); err != nil {
+		return nil, err
+	}
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				ks.scanDir(dir)
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() { close(done) }, nil
+}
+
+// scanDir loads any "*.pem" file in dir that isn't already registered.
+// Files that fail to parse (e.g. encrypted keys, or a write still in
+// progress) are skipped rather than failing the whole scan.
+func (ks *KeyStore) scanDir(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("an error occurred while scanning %s: %s", dir, err)
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".pem") {
+			continue
+		}
+		ks.mu.RLock()
+		known := ks.loadedFiles[entry.Name()]
+		ks.mu.RUnlock()
+		if known {
+			continue
+		}
+		ks.AddKeyFile(filepath.Join(dir, entry.Name()), "", nil)
+	}
+	return nil
+}
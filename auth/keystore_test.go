@@ -0,0 +1,202 @@
+//
+// gosign - Go HTTP signing library for the Joyent Public Cloud and Joyent Manta
+//
+//
+// Copyright (c) 2013 Joyent Inc.
+//
+
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func writeTestKey(t *testing.T, path string, key interface{}) {
+	t.Helper()
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("failed to marshal key: %s", err)
+	}
+	data := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatalf("failed to write key file: %s", err)
+	}
+}
+
+func TestKeyStoreAddKeyFileAndSignerFor(t *testing.T) {
+	dir := t.TempDir()
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate RSA key: %s", err)
+	}
+	keyFile := filepath.Join(dir, "id_rsa.pem")
+	writeTestKey(t, keyFile, rsaKey)
+
+	ks := NewKeyStore()
+	md5Fingerprint, err := ks.AddKeyFile(keyFile, "", nil)
+	if err != nil {
+		t.Fatalf("AddKeyFile failed: %s", err)
+	}
+	sha256Fingerprint, err := FingerprintSHA256(rsaKey.Public())
+	if err != nil {
+		t.Fatalf("FingerprintSHA256 failed: %s", err)
+	}
+
+	if _, ok := ks.SignerFor(md5Fingerprint); !ok {
+		t.Fatalf("expected a signer for the MD5 fingerprint %s", md5Fingerprint)
+	}
+	if _, ok := ks.SignerFor(sha256Fingerprint); !ok {
+		t.Fatalf("expected a signer for the SHA-256 fingerprint %s", sha256Fingerprint)
+	}
+	if _, ok := ks.SignerFor("aa:bb:cc"); ok {
+		t.Fatalf("expected no signer for an unknown fingerprint")
+	}
+}
+
+func TestKeyStorePreferredSigner(t *testing.T) {
+	dir := t.TempDir()
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate RSA key: %s", err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate Ed25519 key: %s", err)
+	}
+
+	ks := NewKeyStore()
+	writeTestKey(t, filepath.Join(dir, "rsa.pem"), rsaKey)
+	if _, err := ks.AddKeyFile(filepath.Join(dir, "rsa.pem"), "", nil); err != nil {
+		t.Fatalf("AddKeyFile(rsa) failed: %s", err)
+	}
+	writeTestKey(t, filepath.Join(dir, "ed25519.pem"), edKey)
+	edFingerprint, err := ks.AddKeyFile(filepath.Join(dir, "ed25519.pem"), "", nil)
+	if err != nil {
+		t.Fatalf("AddKeyFile(ed25519) failed: %s", err)
+	}
+
+	signer, ok := ks.PreferredSigner()
+	if !ok {
+		t.Fatalf("expected a preferred signer")
+	}
+	if signer.KeyID() != edFingerprint {
+		t.Fatalf("expected the Ed25519 key to be preferred over RSA, got key id %s", signer.KeyID())
+	}
+}
+
+func TestKeyStoreWatchPicksUpRotatedKey(t *testing.T) {
+	dir := t.TempDir()
+	ks := NewKeyStore()
+
+	stop, err := ks.Watch(dir, 10*time.Millisecond)
+	if err != nil {
+		t.Fatalf("Watch failed: %s", err)
+	}
+	defer stop()
+
+	if _, ok := ks.PreferredSigner(); ok {
+		t.Fatalf("expected no signer before any key file exists")
+	}
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate RSA key: %s", err)
+	}
+	writeTestKey(t, filepath.Join(dir, "rotated.pem"), rsaKey)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if _, ok := ks.PreferredSigner(); ok {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("Watch did not pick up the new key file in time")
+}
+
+func TestKeyStoreCreateAuthorizationHeaderByFingerprint(t *testing.T) {
+	dir := t.TempDir()
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate RSA key: %s", err)
+	}
+	keyFile := filepath.Join(dir, "id_rsa.pem")
+	writeTestKey(t, keyFile, rsaKey)
+
+	ks := NewKeyStore()
+	fingerprint, err := ks.AddKeyFile(keyFile, "", nil)
+	if err != nil {
+		t.Fatalf("AddKeyFile failed: %s", err)
+	}
+
+	credentials := &Credentials{
+		UserAuthentication: Auth{User: "user"},
+		MantaKeyId:         fingerprint,
+	}
+	headers := http.Header{}
+	headers.Set("Date", "Thu, 05 Jan 2026 21:31:40 GMT")
+	header, err := ks.CreateAuthorizationHeader(headers, credentials, true)
+	if err != nil {
+		t.Fatalf("CreateAuthorizationHeader failed: %s", err)
+	}
+	if !strings.Contains(header, fingerprint) {
+		t.Fatalf("expected header to reference key id %s, got %s", fingerprint, header)
+	}
+
+	// An unknown key id is an error rather than a silent fallback to the
+	// preferred signer, since that would sign with a key that doesn't match
+	// the keyId advertised in the header.
+	credentials.MantaKeyId = "not-a-known-fingerprint"
+	if _, err := ks.CreateAuthorizationHeader(headers, credentials, true); err == nil {
+		t.Fatalf("expected an error for an unknown fingerprint")
+	}
+
+	// An empty key id still falls back to the preferred signer.
+	credentials.MantaKeyId = ""
+	if _, err := ks.CreateAuthorizationHeader(headers, credentials, true); err != nil {
+		t.Fatalf("expected fallback to the preferred signer, got error: %s", err)
+	}
+
+	// An empty KeyStore has no preferred signer to fall back to.
+	empty := NewKeyStore()
+	if _, err := empty.CreateAuthorizationHeader(headers, credentials, true); err == nil {
+		t.Fatalf("expected an error from an empty KeyStore with no matching or preferred key")
+	}
+}
+
+func TestKeyStoreConcurrentAccess(t *testing.T) {
+	dir := t.TempDir()
+	ks := NewKeyStore()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			key, err := rsa.GenerateKey(rand.Reader, 2048)
+			if err != nil {
+				t.Errorf("failed to generate RSA key: %s", err)
+				return
+			}
+			path := filepath.Join(dir, fmt.Sprintf("key-%d.pem", i))
+			writeTestKey(t, path, key)
+			if _, err := ks.AddKeyFile(path, "", nil); err != nil {
+				t.Errorf("AddKeyFile failed: %s", err)
+			}
+			ks.PreferredSigner()
+		}(i)
+	}
+	wg.Wait()
+}
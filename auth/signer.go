@@ -0,0 +1,177 @@
+//
+// gosign - Go HTTP signing library for the Joyent Public Cloud and Joyent Manta
+//
+//
+// Copyright (c) 2013 Joyent Inc.
+//
+
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"sync"
+)
+
+// A Signer produces HTTP Signatures draft signatures without the caller
+// needing to know how or where the private key material lives.
+type Signer interface {
+	// Sign signs data, returning the algorithm used (e.g. "rsa-sha256")
+	// together with the raw signature bytes.
+	Sign(data []byte) (algorithm string, signature []byte, err error)
+	// KeyID returns the identifier the caller should advertise for this
+	// key, e.g. in the Signature header's keyId field.
+	KeyID() string
+}
+
+// DeriveAlgorithm returns the HTTP Signatures draft algorithm name
+// ("rsa-sha256", "ecdsa-sha384", "ed25519", ...) for key, or "" if key is
+// of an unsupported type. It lets callers leave Auth.Algorithm empty and
+// have it inferred from the key itself.
+func DeriveAlgorithm(key crypto.PrivateKey) string {
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		return "rsa-sha256"
+	case *ecdsa.PrivateKey:
+		switch k.Curve.Params().BitSize {
+		case 384:
+			return "ecdsa-sha384"
+		case 521:
+			return "ecdsa-sha512"
+		default:
+			return "ecdsa-sha256"
+		}
+	case ed25519.PrivateKey:
+		return "ed25519"
+	default:
+		return ""
+	}
+}
+
+// PrivateKeySigner signs with a private key read from a PEM-encoded key
+// file, the way CreateAuthorizationHeader has always done. The key is
+// parsed once, on the first call to Sign, and cached thereafter.
+type PrivateKeySigner struct {
+	keyFile    string
+	keyId      string
+	algorithm  string
+	passphrase []byte
+
+	mu  sync.Mutex
+	key crypto.Signer
+}
+
+// NewPrivateKeySigner returns a Signer that lazily loads the RSA, ECDSA or
+// Ed25519 private key in keyFile. If algorithm is empty it is derived from
+// the key once loaded. passphrase is only required when keyFile holds an
+// encrypted PEM block, and may be nil otherwise.
+func NewPrivateKeySigner(keyFile, keyId, algorithm string, passphrase []byte) *PrivateKeySigner {
+	return &PrivateKeySigner{keyFile: keyFile, keyId: keyId, algorithm: algorithm, passphrase: passphrase}
+}
+
+func (s *PrivateKeySigner) KeyID() string {
+	return s.keyId
+}
+
+func (s *PrivateKeySigner) Sign(data []byte) (string, []byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.key == nil {
+		key, err := loadPrivateKey(s.keyFile, s.passphrase)
+		if err != nil {
+			return "", nil, err
+		}
+		s.key = key
+		if s.algorithm == "" {
+			s.algorithm = DeriveAlgorithm(key)
+		}
+	}
+	signed, err := signWithKey(s.key, s.algorithm, data)
+	if err != nil {
+		return "", nil, fmt.Errorf("an error occurred while signing the key: %s", err)
+	}
+	return s.algorithm, signed, nil
+}
+
+// loadPrivateKey reads keyFile and parses the private key within, trying
+// PKCS#8 (covering RSA, ECDSA and Ed25519, and what modern ssh-keygen
+// emits), then SEC 1 ECDSA, then PKCS#1 RSA, decrypting classic
+// ("Proc-Type: 4,ENCRYPTED") PEM blocks with passphrase first.
+func loadPrivateKey(keyFile string, passphrase []byte) (crypto.Signer, error) {
+	keyBytes, err := ioutil.ReadFile(keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("an error occurred while reading the key: %s", err)
+	}
+	block, _ := pem.Decode(keyBytes)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data found in %s", keyFile)
+	}
+	der := block.Bytes
+	if x509.IsEncryptedPEMBlock(block) {
+		der, err = x509.DecryptPEMBlock(block, passphrase)
+		if err != nil {
+			return nil, fmt.Errorf("an error occurred while decrypting the key: %s", err)
+		}
+	}
+	if key, err := x509.ParsePKCS8PrivateKey(der); err == nil {
+		signer, ok := key.(crypto.Signer)
+		if !ok {
+			return nil, fmt.Errorf("key in %s does not support signing", keyFile)
+		}
+		return signer, nil
+	}
+	if key, err := x509.ParseECPrivateKey(der); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(der); err == nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("an error occurred while parsing the key: unrecognised key format")
+}
+
+// signWithKey signs data with key under algorithm, following the HTTP
+// Signatures draft: Ed25519 signs the raw message, everything else signs
+// a digest of it. ECDSA keys produce an ASN.1 DER encoded (r, s) pair, as
+// crypto/ecdsa has done since Go 1.13.
+func signWithKey(key crypto.Signer, algorithm string, data []byte) ([]byte, error) {
+	if _, ok := key.Public().(ed25519.PublicKey); ok {
+		return key.Sign(rand.Reader, data, crypto.Hash(0))
+	}
+	hashFunc := getHashFunction(algorithm)
+	hash := hashFunc.New()
+	hash.Write(data)
+	return key.Sign(rand.Reader, hash.Sum(nil), hashFunc)
+}
+
+// CryptoSigner adapts any crypto.Signer, such as a PKCS#11 token or a KMS
+// client, to the Signer interface.
+type CryptoSigner struct {
+	signer    crypto.Signer
+	keyId     string
+	algorithm string
+}
+
+// NewCryptoSigner returns a Signer backed by signer, advertising keyId and
+// signing with algorithm.
+func NewCryptoSigner(signer crypto.Signer, keyId, algorithm string) *CryptoSigner {
+	return &CryptoSigner{signer: signer, keyId: keyId, algorithm: algorithm}
+}
+
+func (s *CryptoSigner) KeyID() string {
+	return s.keyId
+}
+
+func (s *CryptoSigner) Sign(data []byte) (string, []byte, error) {
+	signed, err := signWithKey(s.signer, s.algorithm, data)
+	if err != nil {
+		return "", nil, fmt.Errorf("an error occurred while signing the key: %s", err)
+	}
+	return s.algorithm, signed, nil
+}
@@ -0,0 +1,81 @@
+//
+// gosign - Go HTTP signing library for the Joyent Public Cloud and Joyent Manta
+//
+//
+// Copyright (c) 2013 Joyent Inc.
+//
+
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// stubCryptoSigner wraps a crypto.Signer so CryptoSigner can be tested
+// without needing a real PKCS#11 token or KMS client.
+type stubCryptoSigner struct {
+	crypto.Signer
+}
+
+func (s stubCryptoSigner) Public() crypto.PublicKey {
+	return s.Signer.Public()
+}
+
+func (s stubCryptoSigner) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	return s.Signer.Sign(rand, digest, opts)
+}
+
+func TestCryptoSignerSignRequestVerifyRequestRoundTrip(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate RSA key: %s", err)
+	}
+	ecdsaKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate ECDSA key: %s", err)
+	}
+
+	tests := []struct {
+		name      string
+		signer    crypto.Signer
+		publicKey crypto.PublicKey
+		algorithm string
+	}{
+		{"rsa", stubCryptoSigner{rsaKey}, &rsaKey.PublicKey, "rsa-sha256"},
+		{"ecdsa", stubCryptoSigner{ecdsaKey}, &ecdsaKey.PublicKey, "ecdsa-sha256"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			signer := NewCryptoSigner(test.signer, "/user/keys/"+test.name, test.algorithm)
+
+			req, err := http.NewRequest("POST", "https://example.com/path", strings.NewReader(`{"hello":"world"}`))
+			if err != nil {
+				t.Fatalf("failed to build request: %s", err)
+			}
+			req.Header.Set("Date", "Thu, 05 Jan 2026 21:31:40 GMT")
+			req.Header.Set("Host", req.URL.Host)
+
+			credentials := &Credentials{
+				UserAuthentication: Auth{User: "user"},
+				MantaKeyId:         test.name,
+			}
+			headers := []string{RequestTarget, "date", "host", "digest"}
+			if err := SignRequest(req, credentials, signer, headers, true); err != nil {
+				t.Fatalf("SignRequest failed: %s", err)
+			}
+
+			if err := VerifyRequest(req, test.publicKey); err != nil {
+				t.Fatalf("VerifyRequest failed: %s", err)
+			}
+		})
+	}
+}
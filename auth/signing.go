@@ -0,0 +1,174 @@
+//
+// gosign - Go HTTP signing library for the Joyent Public Cloud and Joyent Manta
+//
+//
+// Copyright (c) 2013 Joyent Inc.
+//
+
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// RequestTarget is the pseudo-header defined by the HTTP Signatures draft
+// that binds a signature to the request method and path.
+const RequestTarget = "(request-target)"
+
+// signatureHeaderFormat is the layout of the Signature header as consumed by
+// Manta/CloudAPI: http://apidocs.joyent.com/cloudapi/#issuing-requests.
+const signatureHeaderFormat = `keyId="/%s/keys/%s",algorithm="%s",headers="%s",signature="%s"`
+
+var signatureParamRegexp = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+// SignRequest signs req according to the Cavage/IETF HTTP Signatures draft,
+// covering exactly the headers named in headers, in order, and sets the
+// resulting Signature header on req. The "(request-target)" pseudo-header is
+// covered only when headers names it. If headers
+// includes "digest" and req has a body but no Digest header yet, a
+// SHA-256 digest of the body is computed and set before signing. isMantaRequest
+// selects which of credentials' key ids is reported in the keyId field:
+// MantaKeyId for a Manta request, SdcKeyId for a CloudAPI request.
+func SignRequest(req *http.Request, credentials *Credentials, signer Signer, headers []string, isMantaRequest bool) error {
+	if err := ensureDigest(req, headers); err != nil {
+		return err
+	}
+	signingString, err := buildSigningString(req, headers)
+	if err != nil {
+		return err
+	}
+	algorithm, signature, err := GetSignature(signer, signingString)
+	if err != nil {
+		return err
+	}
+	keyId := credentials.SdcKeyId
+	if isMantaRequest {
+		keyId = credentials.MantaKeyId
+	}
+	req.Header.Set("Signature", fmt.Sprintf(signatureHeaderFormat,
+		credentials.UserAuthentication.User, keyId,
+		algorithm, strings.Join(headers, " "), signature))
+	return nil
+}
+
+// VerifyRequest parses the Signature header on req, reconstructs the
+// signing string it covers and verifies it against publicKey. publicKey
+// may be an *rsa.PublicKey, *ecdsa.PublicKey or ed25519.PublicKey.
+func VerifyRequest(req *http.Request, publicKey crypto.PublicKey) error {
+	header := req.Header.Get("Signature")
+	if header == "" {
+		return fmt.Errorf("request has no Signature header")
+	}
+	params := parseSignatureParams(header)
+	algorithm := params["algorithm"]
+	headers := strings.Fields(params["headers"])
+	signature, err := base64.StdEncoding.DecodeString(params["signature"])
+	if err != nil {
+		return fmt.Errorf("invalid signature encoding: %s", err)
+	}
+	signingString, err := buildSigningString(req, headers)
+	if err != nil {
+		return err
+	}
+	return verifySignature(publicKey, algorithm, []byte(signingString), signature)
+}
+
+// verifySignature checks signature over data against publicKey, dispatching
+// on the public key's concrete type the way PrivateKeySigner dispatches on
+// the matching private key's.
+func verifySignature(publicKey crypto.PublicKey, algorithm string, data, signature []byte) error {
+	switch key := publicKey.(type) {
+	case *rsa.PublicKey:
+		hashFunc := getHashFunction(algorithm)
+		hash := hashFunc.New()
+		hash.Write(data)
+		if err := rsa.VerifyPKCS1v15(key, hashFunc, hash.Sum(nil), signature); err != nil {
+			return fmt.Errorf("signature verification failed: %s", err)
+		}
+		return nil
+	case *ecdsa.PublicKey:
+		hashFunc := getHashFunction(algorithm)
+		hash := hashFunc.New()
+		hash.Write(data)
+		if !ecdsa.VerifyASN1(key, hash.Sum(nil), signature) {
+			return fmt.Errorf("signature verification failed")
+		}
+		return nil
+	case ed25519.PublicKey:
+		if !ed25519.Verify(key, data, signature) {
+			return fmt.Errorf("signature verification failed")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported public key type %T", publicKey)
+	}
+}
+
+// parseSignatureParams extracts the key="value" pairs from a Signature
+// header into a map.
+func parseSignatureParams(header string) map[string]string {
+	params := make(map[string]string)
+	for _, match := range signatureParamRegexp.FindAllStringSubmatch(header, -1) {
+		params[match[1]] = match[2]
+	}
+	return params
+}
+
+// buildSigningString constructs the canonical signing string for req
+// covering the given, ordered, header names per the HTTP Signatures draft:
+// names are lower-cased, multiple values for a header are trimmed and
+// joined with ", ", each becomes a "name: value" line, and the lines are
+// joined with "\n" with no trailing newline. The "(request-target)"
+// pseudo-header expands to "<lowercase-method> <request-uri>".
+func buildSigningString(req *http.Request, headers []string) (string, error) {
+	lines := make([]string, len(headers))
+	for i, name := range headers {
+		name = strings.ToLower(name)
+		if name == RequestTarget {
+			lines[i] = fmt.Sprintf("%s: %s %s", RequestTarget, strings.ToLower(req.Method), req.URL.RequestURI())
+			continue
+		}
+		values := req.Header[http.CanonicalHeaderKey(name)]
+		if len(values) == 0 {
+			return "", fmt.Errorf("missing header %q required for signing", name)
+		}
+		trimmed := make([]string, len(values))
+		for j, v := range values {
+			trimmed[j] = strings.TrimSpace(v)
+		}
+		lines[i] = fmt.Sprintf("%s: %s", name, strings.Join(trimmed, ", "))
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// ensureDigest computes and sets a Digest header on req, via ComputeDigest,
+// when "digest" is requested for signing, req carries a body, and no
+// Digest header has been set already.
+func ensureDigest(req *http.Request, headers []string) error {
+	wantsDigest := false
+	for _, name := range headers {
+		if strings.EqualFold(name, "digest") {
+			wantsDigest = true
+			break
+		}
+	}
+	if !wantsDigest || req.Body == nil || req.Header.Get("Digest") != "" {
+		return nil
+	}
+	header, wrapped, err := ComputeDigest(req.Body, "SHA-256")
+	if err != nil {
+		return err
+	}
+	req.Body = ioutil.NopCloser(wrapped)
+	req.Header.Set("Digest", header)
+	return nil
+}
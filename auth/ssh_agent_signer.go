@@ -0,0 +1,128 @@
+//
+// gosign - Go HTTP signing library for the Joyent Public Cloud and Joyent Manta
+//
+//
+// Copyright (c) 2013 Joyent Inc.
+//
+
+package auth
+
+import (
+	"encoding/asn1"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// SSHAgentSigner signs via a running ssh-agent reachable at $SSH_AUTH_SOCK,
+// so a password-protected key or a hardware token never has to expose its
+// key material to this process.
+type SSHAgentSigner struct {
+	agent     agent.ExtendedAgent
+	publicKey ssh.PublicKey
+	keyId     string
+	algorithm string
+}
+
+// NewSSHAgentSigner connects to the ssh-agent at $SSH_AUTH_SOCK and returns
+// a Signer for the agent-held key matching keyId (the key's comment, or
+// either its SHA-256 or legacy MD5 SSH fingerprint, as reported by
+// `ssh-add -l`/`ssh-add -l -E md5`).
+func NewSSHAgentSigner(keyId string) (*SSHAgentSigner, error) {
+	socket := os.Getenv("SSH_AUTH_SOCK")
+	if socket == "" {
+		return nil, fmt.Errorf("SSH_AUTH_SOCK is not set; no ssh-agent to sign with")
+	}
+	conn, err := net.Dial("unix", socket)
+	if err != nil {
+		return nil, fmt.Errorf("an error occurred while connecting to ssh-agent: %s", err)
+	}
+	agentClient := agent.NewClient(conn)
+	keys, err := agentClient.List()
+	if err != nil {
+		return nil, fmt.Errorf("an error occurred while listing ssh-agent keys: %s", err)
+	}
+	for _, key := range keys {
+		if key.Comment == keyId || ssh.FingerprintSHA256(key) == keyId || ssh.FingerprintLegacyMD5(key) == keyId {
+			return &SSHAgentSigner{
+				agent:     agentClient,
+				publicKey: key,
+				keyId:     keyId,
+				algorithm: algorithmForPublicKey(key),
+			}, nil
+		}
+	}
+	return nil, fmt.Errorf("no key matching %q loaded in ssh-agent", keyId)
+}
+
+func (s *SSHAgentSigner) KeyID() string {
+	return s.keyId
+}
+
+func (s *SSHAgentSigner) Sign(data []byte) (string, []byte, error) {
+	// Plain Sign (flags=0) asks the agent for its legacy ssh-rsa (SHA-1)
+	// format for RSA keys; request rsa-sha2-256 explicitly so the
+	// algorithm we report back actually matches what was signed.
+	var flags agent.SignatureFlags
+	if s.publicKey.Type() == ssh.KeyAlgoRSA {
+		flags = agent.SignatureFlagRsaSha256
+	}
+	signature, err := s.agent.SignWithFlags(s.publicKey, data, flags)
+	if err != nil {
+		return "", nil, fmt.Errorf("an error occurred while signing with ssh-agent: %s", err)
+	}
+	blob := signature.Blob
+	if isECDSAKeyType(s.publicKey.Type()) {
+		blob, err = ecdsaSSHSignatureToASN1(blob)
+		if err != nil {
+			return "", nil, fmt.Errorf("an error occurred while decoding the ssh-agent signature: %s", err)
+		}
+	}
+	return s.algorithm, blob, nil
+}
+
+func isECDSAKeyType(keyType string) bool {
+	switch keyType {
+	case ssh.KeyAlgoECDSA256, ssh.KeyAlgoECDSA384, ssh.KeyAlgoECDSA521:
+		return true
+	default:
+		return false
+	}
+}
+
+// ecdsaSSHSignatureToASN1 converts an ECDSA signature blob as returned by
+// the ssh-agent wire protocol — an (r, s) pair encoded as two SSH mpints —
+// into the ASN.1 DER encoding crypto/ecdsa and this package otherwise use,
+// mirroring what golang.org/x/crypto/ssh's own wrappedSigner does in
+// reverse for local ECDSA signing.
+func ecdsaSSHSignatureToASN1(blob []byte) ([]byte, error) {
+	var sig struct {
+		R *big.Int
+		S *big.Int
+	}
+	if err := ssh.Unmarshal(blob, &sig); err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(sig)
+}
+
+// algorithmForPublicKey maps an ssh-agent key's type to the HTTP Signatures
+// algorithm name it signs with.
+func algorithmForPublicKey(key ssh.PublicKey) string {
+	switch key.Type() {
+	case ssh.KeyAlgoED25519:
+		return "ed25519"
+	case ssh.KeyAlgoECDSA256:
+		return "ecdsa-sha256"
+	case ssh.KeyAlgoECDSA384:
+		return "ecdsa-sha384"
+	case ssh.KeyAlgoECDSA521:
+		return "ecdsa-sha512"
+	default:
+		return "rsa-sha256"
+	}
+}
@@ -0,0 +1,122 @@
+//
+// gosign - Go HTTP signing library for the Joyent Public Cloud and Joyent Manta
+//
+//
+// Copyright (c) 2013 Joyent Inc.
+//
+
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"net"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// startTestAgent serves an in-memory ssh-agent keyring over a unix socket
+// at $SSH_AUTH_SOCK, the way a real ssh-agent would, so NewSSHAgentSigner
+// can be exercised end-to-end without a real agent process.
+func startTestAgent(t *testing.T) agent.Agent {
+	t.Helper()
+	keyring := agent.NewKeyring()
+
+	socket := filepath.Join(t.TempDir(), "agent.sock")
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatalf("failed to listen on %s: %s", socket, err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go agent.ServeAgent(keyring, conn)
+		}
+	}()
+
+	t.Setenv("SSH_AUTH_SOCK", socket)
+	return keyring
+}
+
+func signAndVerifyViaAgent(t *testing.T, keyring agent.Agent, privateKey, publicKey interface{}, comment string) {
+	t.Helper()
+	if err := keyring.Add(agent.AddedKey{PrivateKey: privateKey, Comment: comment}); err != nil {
+		t.Fatalf("failed to add key to agent: %s", err)
+	}
+
+	signer, err := NewSSHAgentSigner(comment)
+	if err != nil {
+		t.Fatalf("NewSSHAgentSigner failed: %s", err)
+	}
+
+	req, err := http.NewRequest("POST", "https://example.com/path", strings.NewReader(`{"hello":"world"}`))
+	if err != nil {
+		t.Fatalf("failed to build request: %s", err)
+	}
+	req.Header.Set("Date", "Thu, 05 Jan 2026 21:31:40 GMT")
+	req.Header.Set("Host", req.URL.Host)
+
+	credentials := &Credentials{
+		UserAuthentication: Auth{User: "user"},
+		MantaKeyId:         comment,
+	}
+	headers := []string{RequestTarget, "date", "host", "digest"}
+	if err := SignRequest(req, credentials, signer, headers, true); err != nil {
+		t.Fatalf("SignRequest failed: %s", err)
+	}
+
+	if err := VerifyRequest(req, publicKey); err != nil {
+		t.Fatalf("VerifyRequest failed: %s", err)
+	}
+}
+
+func TestSSHAgentSignerRSA(t *testing.T) {
+	keyring := startTestAgent(t)
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate RSA key: %s", err)
+	}
+	signAndVerifyViaAgent(t, keyring, key, &key.PublicKey, "rsa-key")
+}
+
+func TestSSHAgentSignerECDSA(t *testing.T) {
+	curves := []struct {
+		name  string
+		curve elliptic.Curve
+	}{
+		{"p256", elliptic.P256()},
+		{"p384", elliptic.P384()},
+		{"p521", elliptic.P521()},
+	}
+	for _, test := range curves {
+		t.Run(test.name, func(t *testing.T) {
+			keyring := startTestAgent(t)
+			key, err := ecdsa.GenerateKey(test.curve, rand.Reader)
+			if err != nil {
+				t.Fatalf("failed to generate ECDSA key: %s", err)
+			}
+			signAndVerifyViaAgent(t, keyring, key, &key.PublicKey, "ecdsa-"+test.name)
+		})
+	}
+}
+
+func TestSSHAgentSignerEd25519(t *testing.T) {
+	keyring := startTestAgent(t)
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate Ed25519 key: %s", err)
+	}
+	signAndVerifyViaAgent(t, keyring, privateKey, publicKey, "ed25519-key")
+}